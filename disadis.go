@@ -49,6 +49,11 @@ func signalHandler(sig <-chan os.Signal, logw Reopener) {
 		switch s {
 		case syscall.SIGUSR1:
 			logw.Reopen()
+		case syscall.SIGHUP:
+			// TODO: disseminator.Run does not hand back the
+			// fedora.CachedFedora it builds (if any), so there is
+			// nothing here to call PurgeAll on yet. SIGHUP is a
+			// no-op until disseminator exposes one.
 		}
 	}
 }
@@ -0,0 +1,243 @@
+package fedora
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheOpts configures a CachedFedora created by NewCached.
+type CacheOpts struct {
+	// MaxBytes bounds the total size of cached datastream content held in
+	// memory. 0 means a default of 64MB.
+	MaxBytes int64
+	// InfoTTL is how long a GetDatastreamInfo probe is trusted before it is
+	// repeated, so a hot object does not cost a Fedora round trip on every
+	// request. 0 means always probe.
+	InfoTTL time.Duration
+}
+
+// NewCached wraps inner in an in-memory, version-aware cache of datastream
+// bytes and DsInfo, keyed by (id, dsname, DsInfo.VersionID or Checksum).
+// GetDatastream first makes the cheap GetDatastreamInfo call, compares the
+// result against whatever is cached, and serves the cached bytes on a hit;
+// on a miss it streams inner's response to the caller while teeing it into
+// the cache. The concrete type returned also implements Purge, for callers
+// that need to invalidate an object by hand.
+func NewCached(inner Fedora, opts CacheOpts) Fedora {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 64 << 20
+	}
+	return &CachedFedora{
+		Fedora:    inner,
+		maxBytes:  opts.MaxBytes,
+		infoTTL:   opts.InfoTTL,
+		entries:   make(map[string]*cacheEntry),
+		infoCache: make(map[string]infoProbe),
+	}
+}
+
+// CachedFedora is the concrete type behind NewCached, exported so a caller
+// holding only a Fedora can recover it with a type assertion in order to
+// call Purge.
+type CachedFedora struct {
+	Fedora
+	maxBytes int64
+	infoTTL  time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]*cacheEntry
+	order     []string // LRU order of entries, oldest first
+	curBytes  int64
+	infoCache map[string]infoProbe
+}
+
+type cacheEntry struct {
+	info    DsInfo
+	ci      ContentInfo
+	content []byte
+}
+
+type infoProbe struct {
+	info DsInfo
+	at   time.Time
+}
+
+// GetDatastream serves id/dsname from cache when the cached copy's version
+// (or, failing that, checksum) still matches what Fedora reports, and falls
+// through to inner otherwise, caching the result as it streams to the
+// caller.
+func (cf *CachedFedora) GetDatastream(id, dsname string) (io.ReadCloser, ContentInfo, error) {
+	key := id + "/" + dsname
+	info, err := cf.probeInfo(key, id, dsname)
+	if err != nil {
+		return nil, ContentInfo{}, err
+	}
+
+	cf.mu.Lock()
+	if entry, ok := cf.entries[key]; ok && sameVersion(entry.info, info) {
+		content := entry.content
+		ci := entry.ci
+		cf.touch(key)
+		cf.mu.Unlock()
+		return ioutil.NopCloser(bytes.NewReader(content)), ci, nil
+	}
+	cf.mu.Unlock()
+
+	rc, ci, err := cf.Fedora.GetDatastream(id, dsname)
+	if err != nil {
+		return nil, ci, err
+	}
+	return newTeeCacher(rc, cf, key, info, ci), ci, nil
+}
+
+// GetDatastreamInfo is a pass-through to inner, sharing the same info probe
+// cache GetDatastream uses so a call to one warms the other.
+func (cf *CachedFedora) GetDatastreamInfo(id, dsname string) (DsInfo, error) {
+	return cf.probeInfo(id+"/"+dsname, id, dsname)
+}
+
+// Purge removes every cache entry belonging to pid, e.g. when disadis learns
+// that an object changed out from under it.
+func (cf *CachedFedora) Purge(pid string) {
+	prefix := pid + "/"
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	for key, entry := range cf.entries {
+		if strings.HasPrefix(key, prefix) {
+			cf.curBytes -= int64(len(entry.content))
+			delete(cf.entries, key)
+			cf.removeFromOrder(key)
+		}
+	}
+	for key := range cf.infoCache {
+		if strings.HasPrefix(key, prefix) {
+			delete(cf.infoCache, key)
+		}
+	}
+}
+
+// PurgeAll clears the entire cache, e.g. in response to a SIGHUP telling
+// disadis to drop everything it has cached, since there is no pid to scope
+// the purge to.
+func (cf *CachedFedora) PurgeAll() {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	cf.entries = make(map[string]*cacheEntry)
+	cf.order = nil
+	cf.curBytes = 0
+	cf.infoCache = make(map[string]infoProbe)
+}
+
+func (cf *CachedFedora) probeInfo(key, id, dsname string) (DsInfo, error) {
+	cf.mu.Lock()
+	if p, ok := cf.infoCache[key]; ok && cf.infoTTL > 0 && time.Since(p.at) < cf.infoTTL {
+		cf.mu.Unlock()
+		return p.info, nil
+	}
+	cf.mu.Unlock()
+
+	info, err := cf.Fedora.GetDatastreamInfo(id, dsname)
+	if err != nil {
+		return DsInfo{}, err
+	}
+	cf.mu.Lock()
+	cf.infoCache[key] = infoProbe{info: info, at: time.Now()}
+	cf.mu.Unlock()
+	return info, nil
+}
+
+// store records content as the cached value for key, evicting the oldest
+// entries if needed to stay under maxBytes. An entry larger than maxBytes on
+// its own is left uncached rather than evicting everything else to make
+// room for it.
+func (cf *CachedFedora) store(key string, info DsInfo, ci ContentInfo, content []byte) {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	if old, ok := cf.entries[key]; ok {
+		cf.curBytes -= int64(len(old.content))
+		cf.removeFromOrder(key)
+		delete(cf.entries, key)
+	}
+	size := int64(len(content))
+	if size > cf.maxBytes {
+		return
+	}
+	for cf.curBytes+size > cf.maxBytes && len(cf.order) > 0 {
+		oldest := cf.order[0]
+		cf.order = cf.order[1:]
+		if e, ok := cf.entries[oldest]; ok {
+			cf.curBytes -= int64(len(e.content))
+			delete(cf.entries, oldest)
+		}
+	}
+	cf.entries[key] = &cacheEntry{info: info, ci: ci, content: content}
+	cf.order = append(cf.order, key)
+	cf.curBytes += size
+}
+
+func (cf *CachedFedora) touch(key string) {
+	cf.removeFromOrder(key)
+	cf.order = append(cf.order, key)
+}
+
+func (cf *CachedFedora) removeFromOrder(key string) {
+	for i, k := range cf.order {
+		if k == key {
+			cf.order = append(cf.order[:i], cf.order[i+1:]...)
+			return
+		}
+	}
+}
+
+// sameVersion reports whether a and b describe the same datastream version,
+// preferring VersionID and falling back to Checksum if neither has one. If
+// neither field is populated on either side there is no way to tell, so the
+// versions are treated as different and the entry is treated as stale.
+func sameVersion(a, b DsInfo) bool {
+	if a.VersionID != "" || b.VersionID != "" {
+		return a.VersionID == b.VersionID
+	}
+	if a.Checksum != "" || b.Checksum != "" {
+		return a.Checksum == b.Checksum
+	}
+	return false
+}
+
+// teeCacher streams a datastream body to the caller while copying it into a
+// buffer, handing the buffer to the cache only once the body has been read
+// to completion.
+type teeCacher struct {
+	rc   io.ReadCloser
+	tee  io.Reader
+	buf  *bytes.Buffer
+	cf   *CachedFedora
+	key  string
+	info DsInfo
+	ci   ContentInfo
+	eof  bool
+}
+
+func newTeeCacher(rc io.ReadCloser, cf *CachedFedora, key string, info DsInfo, ci ContentInfo) *teeCacher {
+	buf := new(bytes.Buffer)
+	return &teeCacher{rc: rc, tee: io.TeeReader(rc, buf), buf: buf, cf: cf, key: key, info: info, ci: ci}
+}
+
+func (t *teeCacher) Read(p []byte) (int, error) {
+	n, err := t.tee.Read(p)
+	if err == io.EOF {
+		t.eof = true
+	}
+	return n, err
+}
+
+func (t *teeCacher) Close() error {
+	err := t.rc.Close()
+	if t.eof {
+		t.cf.store(t.key, t.info, t.ci, t.buf.Bytes())
+	}
+	return err
+}
@@ -0,0 +1,184 @@
+package fedora
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// countingFedora counts GetDatastream calls that reach it, so a test can
+// tell whether CachedFedora served a request from cache or fell through to
+// inner.
+type countingFedora struct {
+	Fedora
+	gets int
+}
+
+func (c *countingFedora) GetDatastream(id, dsname string) (io.ReadCloser, ContentInfo, error) {
+	c.gets++
+	return c.Fedora.GetDatastream(id, dsname)
+}
+
+func drainAndClose(t *testing.T, rc io.ReadCloser) string {
+	t.Helper()
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCachedFedoraHitAndVersionInvalidation(t *testing.T) {
+	tf := NewTestFedora()
+	tf.Set("pid:1", "DS", DsInfo{}, []byte("version one"))
+	counting := &countingFedora{Fedora: tf}
+	cached := NewCached(counting, CacheOpts{}).(*CachedFedora)
+
+	rc, _, err := cached.GetDatastream("pid:1", "DS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := drainAndClose(t, rc); got != "version one" {
+		t.Fatalf("got %q", got)
+	}
+	if counting.gets != 1 {
+		t.Fatalf("gets after first fetch = %d, want 1", counting.gets)
+	}
+
+	rc2, _, err := cached.GetDatastream("pid:1", "DS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := drainAndClose(t, rc2); got != "version one" {
+		t.Fatalf("got %q from what should have been a cache hit", got)
+	}
+	if counting.gets != 1 {
+		t.Fatalf("gets after cache hit = %d, want still 1", counting.gets)
+	}
+
+	if err := tf.ModifyDatastreamByValue("pid:1", "DS", strings.NewReader("version two"), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	rc3, _, err := cached.GetDatastream("pid:1", "DS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := drainAndClose(t, rc3); got != "version two" {
+		t.Fatalf("got %q after version bump, want %q", got, "version two")
+	}
+	if counting.gets != 2 {
+		t.Fatalf("gets after version bump = %d, want 2", counting.gets)
+	}
+}
+
+func TestCachedFedoraEvictsOldestToStayUnderMaxBytes(t *testing.T) {
+	tf := NewTestFedora()
+	tf.Set("pid:1", "A", DsInfo{}, []byte("aaaaaaaaaa")) // 10 bytes
+	tf.Set("pid:2", "B", DsInfo{}, []byte("bbbbbbbbbb")) // 10 bytes
+	cached := NewCached(tf, CacheOpts{MaxBytes: 15}).(*CachedFedora)
+
+	drain := func(id, dsname string) {
+		rc, _, err := cached.GetDatastream(id, dsname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		drainAndClose(t, rc)
+	}
+	drain("pid:1", "A")
+	drain("pid:2", "B")
+
+	cached.mu.Lock()
+	_, haveA := cached.entries["pid:1/A"]
+	_, haveB := cached.entries["pid:2/B"]
+	cached.mu.Unlock()
+	if haveA {
+		t.Fatal("oldest entry should have been evicted to stay under MaxBytes")
+	}
+	if !haveB {
+		t.Fatal("most recently fetched entry should still be cached")
+	}
+}
+
+func TestCachedFedoraOversizedEntryIsNotCached(t *testing.T) {
+	tf := NewTestFedora()
+	tf.Set("pid:1", "BIG", DsInfo{}, []byte("0123456789"))
+	cached := NewCached(tf, CacheOpts{MaxBytes: 5}).(*CachedFedora)
+
+	rc, _, err := cached.GetDatastream("pid:1", "BIG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := drainAndClose(t, rc); got != "0123456789" {
+		t.Fatalf("got %q", got)
+	}
+
+	cached.mu.Lock()
+	_, have := cached.entries["pid:1/BIG"]
+	curBytes := cached.curBytes
+	cached.mu.Unlock()
+	if have {
+		t.Fatal("an entry larger than MaxBytes should not be cached")
+	}
+	if curBytes != 0 {
+		t.Fatalf("curBytes = %d, want 0", curBytes)
+	}
+}
+
+func TestCachedFedoraPurge(t *testing.T) {
+	tf := NewTestFedora()
+	tf.Set("pid:1", "DS", DsInfo{}, []byte("content"))
+	counting := &countingFedora{Fedora: tf}
+	cached := NewCached(counting, CacheOpts{}).(*CachedFedora)
+
+	drain := func() {
+		rc, _, err := cached.GetDatastream("pid:1", "DS")
+		if err != nil {
+			t.Fatal(err)
+		}
+		drainAndClose(t, rc)
+	}
+	drain()
+	if counting.gets != 1 {
+		t.Fatalf("gets = %d, want 1", counting.gets)
+	}
+	drain()
+	if counting.gets != 1 {
+		t.Fatalf("gets after cache hit = %d, want still 1", counting.gets)
+	}
+
+	cached.Purge("pid:1")
+	drain()
+	if counting.gets != 2 {
+		t.Fatalf("gets after Purge = %d, want 2", counting.gets)
+	}
+}
+
+func TestCachedFedoraPurgeAll(t *testing.T) {
+	tf := NewTestFedora()
+	tf.Set("pid:1", "DS", DsInfo{}, []byte("content"))
+	counting := &countingFedora{Fedora: tf}
+	cached := NewCached(counting, CacheOpts{}).(*CachedFedora)
+
+	drain := func() {
+		rc, _, err := cached.GetDatastream("pid:1", "DS")
+		if err != nil {
+			t.Fatal(err)
+		}
+		drainAndClose(t, rc)
+	}
+	drain()
+	drain()
+	if counting.gets != 1 {
+		t.Fatalf("gets before PurgeAll = %d, want 1", counting.gets)
+	}
+
+	cached.PurgeAll()
+	drain()
+	if counting.gets != 2 {
+		t.Fatalf("gets after PurgeAll = %d, want 2", counting.gets)
+	}
+}
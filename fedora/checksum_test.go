@@ -0,0 +1,111 @@
+package fedora
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+// stubFedora is a minimal Fedora that always returns the same canned
+// datastream, for exercising decorators like NewChecksumVerifier in
+// isolation.
+type stubFedora struct {
+	content []byte
+	ci      ContentInfo
+	info    DsInfo
+}
+
+func (s *stubFedora) GetDatastream(id, dsname string) (io.ReadCloser, ContentInfo, error) {
+	return ioutil.NopCloser(bytes.NewReader(s.content)), s.ci, nil
+}
+
+func (s *stubFedora) GetDatastreamInfo(id, dsname string) (DsInfo, error) {
+	return s.info, nil
+}
+
+func (s *stubFedora) GetRelationships(id string) ([]Relationship, error) {
+	return nil, nil
+}
+
+func md5Hex(b []byte) string {
+	sum := md5.Sum(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestChecksumVerifierMatch(t *testing.T) {
+	content := []byte("hello world")
+	stub := &stubFedora{content: content, ci: ContentInfo{MD5: md5Hex(content)}}
+	cv := NewChecksumVerifier(stub)
+
+	rc, _, err := cv.GetDatastream("id", "DS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll returned error for matching checksum: %v", err)
+	}
+	if !bytes.Equal(data, content) {
+		t.Fatalf("got %q, want %q", data, content)
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatalf("Close returned error for matching checksum: %v", err)
+	}
+}
+
+func TestChecksumVerifierMismatchOnRead(t *testing.T) {
+	content := []byte("hello world")
+	stub := &stubFedora{content: content, ci: ContentInfo{MD5: md5Hex([]byte("not the same"))}}
+	cv := NewChecksumVerifier(stub)
+
+	rc, _, err := cv.GetDatastream("id", "DS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	if _, err := ioutil.ReadAll(rc); err != ErrChecksumMismatch {
+		t.Fatalf("ReadAll error = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestChecksumVerifierMismatchOnEarlyClose(t *testing.T) {
+	content := []byte("hello world")
+	stub := &stubFedora{content: content, ci: ContentInfo{MD5: md5Hex([]byte("not the same"))}}
+	cv := NewChecksumVerifier(stub)
+
+	rc, _, err := cv.GetDatastream("id", "DS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err := rc.Read(buf); err != nil {
+		t.Fatalf("short Read returned error: %v", err)
+	}
+	if err := rc.Close(); err != ErrChecksumMismatch {
+		t.Fatalf("Close error = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestChecksumVerifierNoKnownChecksumPassesThrough(t *testing.T) {
+	content := []byte("hello world")
+	stub := &stubFedora{content: content}
+	cv := NewChecksumVerifier(stub)
+
+	rc, _, err := cv.GetDatastream("id", "DS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll returned error with no checksum to verify against: %v", err)
+	}
+	if !bytes.Equal(data, content) {
+		t.Fatalf("got %q, want %q", data, content)
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+}
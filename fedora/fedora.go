@@ -4,20 +4,34 @@ package fedora
 
 import (
 	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Exported errors
 var (
 	ErrNotFound      = errors.New("Item Not Found in Fedora")
 	ErrNotAuthorized = errors.New("Access Denied")
+	// ErrChecksumMismatch is returned by Read or Close on the stream returned
+	// from GetDatastream (when wrapped by NewChecksumVerifier) if the bytes
+	// read do not match the checksum known for the datastream, or by a
+	// FedoraWriter call if Fedora rejects the write because the supplied
+	// checksum does not match the bytes sent.
+	ErrChecksumMismatch = errors.New("datastream checksum does not match")
 )
 
 // Fedora represents a Fedora Commons server. The exact nature of the
@@ -29,6 +43,9 @@ type Fedora interface {
 	// GetDatastreamInfo returns the metadata Fedora stores about the named
 	// datastream.
 	GetDatastreamInfo(id, dsname string) (DsInfo, error)
+	// GetRelationships returns the RELS-EXT relationships asserted about
+	// object id.
+	GetRelationships(id string) ([]Relationship, error)
 }
 
 // ContentInfo holds the most basic metadata about a datastream.
@@ -49,17 +66,72 @@ type ContentInfo struct {
 // The namespace is expected to have the form "temp:", and it will be prefixed
 // to all object identifiers.
 // The returned structure does not buffer or cache Fedora responses.
-func NewRemote(fedoraPath string, namespace string) Fedora {
-	rf := &remoteFedora{hostpath: fedoraPath, namespace: namespace}
+func NewRemote(fedoraPath string, namespace string, opts ...Option) Fedora {
+	rf := &remoteFedora{hostpath: fedoraPath, namespace: namespace, client: http.DefaultClient}
 	if rf.hostpath[len(rf.hostpath)-1] != '/' {
 		rf.hostpath = rf.hostpath + "/"
 	}
+	for _, opt := range opts {
+		opt(rf)
+	}
 	return rf
 }
 
+// Option configures optional behavior of a remoteFedora created by
+// NewRemote.
+type Option func(*remoteFedora)
+
+// WithTransport makes NewRemote issue every request through rt instead of
+// http.DefaultTransport, e.g. to add request tracing or a custom dialer.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(rf *remoteFedora) { rf.client = &http.Client{Transport: rt} }
+}
+
+// WithObserver registers fn to be called once every Fedora request
+// completes, successfully or not. Observers are called synchronously, in
+// the order they were registered, after the call they describe finishes.
+func WithObserver(fn Observer) Option {
+	return func(rf *remoteFedora) { rf.observers = append(rf.observers, fn) }
+}
+
+// CallInfo describes one completed request to Fedora, as reported to an
+// Observer.
+type CallInfo struct {
+	ID       string // object id, or "" for object-level calls such as IngestObject
+	Dsname   string // datastream name, or "" if the call was not datastream-scoped
+	Method   string // HTTP method used
+	Status   int    // HTTP status code Fedora returned; 0 if err prevented a response
+	Bytes    int64  // Content-Length of the response, or -1 if unknown
+	Duration time.Duration
+	Err      error
+}
+
+// Observer is called once for every request issued to Fedora: every
+// GetDatastream, GetDatastreamInfo, and FedoraWriter call. It lets operators
+// see which PIDs are hot, how Fedora is performing, and where error storms
+// originate, none of which is visible when http.Get is called inline.
+type Observer func(CallInfo)
+
 type remoteFedora struct {
 	hostpath  string
 	namespace string
+	client    *http.Client
+	observers []Observer
+}
+
+// notify reports a completed call to every registered observer.
+func (rf *remoteFedora) notify(id, dsname, method string, start time.Time, r *http.Response, err error) {
+	if len(rf.observers) == 0 {
+		return
+	}
+	ci := CallInfo{ID: id, Dsname: dsname, Method: method, Duration: time.Since(start), Err: err, Bytes: -1}
+	if r != nil {
+		ci.Status = r.StatusCode
+		ci.Bytes = r.ContentLength
+	}
+	for _, fn := range rf.observers {
+		fn(ci)
+	}
 }
 
 // returns the contents of the datastream `dsname`.
@@ -68,7 +140,9 @@ func (rf *remoteFedora) GetDatastream(id, dsname string) (io.ReadCloser, Content
 	// TODO: make this joining smarter wrt not duplicating slashes
 	var path = rf.hostpath + "objects/" + rf.namespace + id + "/datastreams/" + dsname + "/content"
 	var info ContentInfo
-	r, err := http.Get(path)
+	start := time.Now()
+	r, err := rf.client.Get(path)
+	rf.notify(id, dsname, http.MethodGet, start, r, err)
 	if err != nil {
 		return nil, info, err
 	}
@@ -112,7 +186,9 @@ func (rf *remoteFedora) GetDatastreamInfo(id, dsname string) (DsInfo, error) {
 	// TODO: make this joining smarter wrt not duplicating slashes
 	var path = rf.hostpath + "objects/" + rf.namespace + id + "/datastreams/" + dsname + "?format=xml"
 	var info DsInfo
-	r, err := http.Get(path)
+	start := time.Now()
+	r, err := rf.client.Get(path)
+	rf.notify(id, dsname, http.MethodGet, start, r, err)
 	if err != nil {
 		return info, err
 	}
@@ -137,6 +213,238 @@ func (rf *remoteFedora) GetDatastreamInfo(id, dsname string) (DsInfo, error) {
 	return info, err
 }
 
+// FedoraWriter is the write-side counterpart to Fedora. It is kept as a
+// separate interface since most of disadis only ever reads datastreams, and
+// an implementation (such as the CachedFedora returned by NewCached, which
+// does not implement FedoraWriter) may have nothing sensible to do for writes.
+type FedoraWriter interface {
+	// AddDatastream creates a new datastream dsname on object id with the
+	// given content and MIME type.
+	AddDatastream(id, dsname string, content io.Reader, mimetype string) error
+	// ModifyDatastreamByValue replaces the content of an existing
+	// datastream.
+	ModifyDatastreamByValue(id, dsname string, content io.Reader, mimetype string) error
+	// ModifyDatastreamByReference points an existing datastream at an
+	// external location instead of uploading new content for it.
+	ModifyDatastreamByReference(id, dsname, location string) error
+	// PurgeDatastream removes a datastream from an object.
+	PurgeDatastream(id, dsname string) error
+	// IngestObject creates a new object from a FOXML document and returns
+	// its pid.
+	IngestObject(foxml io.Reader) (string, error)
+	// PurgeObject removes an object and all its datastreams.
+	PurgeObject(id string) error
+}
+
+// AddDatastream creates a new datastream dsname on object id, streaming
+// content as a multipart/form-data upload so large binaries are never
+// buffered in memory.
+func (rf *remoteFedora) AddDatastream(id, dsname string, content io.Reader, mimetype string) error {
+	var path = rf.hostpath + "objects/" + rf.namespace + id + "/datastreams/" + dsname
+	return rf.putMultipart(id, dsname, "POST", path, content, mimetype)
+}
+
+// ModifyDatastreamByValue replaces the content of datastream dsname,
+// streaming it the same way AddDatastream does.
+func (rf *remoteFedora) ModifyDatastreamByValue(id, dsname string, content io.Reader, mimetype string) error {
+	var path = rf.hostpath + "objects/" + rf.namespace + id + "/datastreams/" + dsname
+	return rf.putMultipart(id, dsname, "PUT", path, content, mimetype)
+}
+
+// ModifyDatastreamByReference points datastream dsname at the external
+// location instead of uploading content for it.
+func (rf *remoteFedora) ModifyDatastreamByReference(id, dsname, location string) error {
+	var path = rf.hostpath + "objects/" + rf.namespace + id + "/datastreams/" + dsname +
+		"?dsLocation=" + url.QueryEscape(location)
+	return rf.do(id, dsname, http.MethodPut, path, nil)
+}
+
+// PurgeDatastream removes datastream dsname from object id.
+func (rf *remoteFedora) PurgeDatastream(id, dsname string) error {
+	var path = rf.hostpath + "objects/" + rf.namespace + id + "/datastreams/" + dsname
+	return rf.do(id, dsname, http.MethodDelete, path, nil)
+}
+
+// IngestObject creates a new object from the given FOXML document, returning
+// the pid Fedora assigned it.
+func (rf *remoteFedora) IngestObject(foxml io.Reader) (string, error) {
+	var path = rf.hostpath + "objects/new"
+	body, err := rf.doBody("", "", http.MethodPost, path, foxml)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// PurgeObject removes object id and all of its datastreams.
+func (rf *remoteFedora) PurgeObject(id string) error {
+	var path = rf.hostpath + "objects/" + rf.namespace + id
+	return rf.do(id, "", http.MethodDelete, path, nil)
+}
+
+// putMultipart streams content as the single file part of a
+// multipart/form-data request, without buffering it fully in memory.
+func (rf *remoteFedora) putMultipart(id, dsname, method, path string, content io.Reader, mimetype string) error {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Disposition": {`form-data; name="content"; filename="content"`},
+			"Content-Type":        {mimetype},
+		})
+		if err == nil {
+			_, err = io.Copy(part, content)
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	req, err := http.NewRequest(method, path, pr)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return rf.doRequest(id, dsname, req)
+}
+
+// do issues a request with the given body (nil for none) and translates Fedora's response status
+// into one of the typed errors above.
+func (rf *remoteFedora) do(id, dsname, method, path string, body io.Reader) error {
+	req, err := http.NewRequest(method, path, body)
+	if err != nil {
+		return err
+	}
+	return rf.doRequest(id, dsname, req)
+}
+
+func (rf *remoteFedora) doRequest(id, dsname string, req *http.Request) error {
+	start := time.Now()
+	r, err := rf.client.Do(req)
+	rf.notify(id, dsname, req.Method, start, r, err)
+	if err != nil {
+		return err
+	}
+	r.Body.Close()
+	return translateStatus(r.StatusCode)
+}
+
+// doBody is like do, but returns the response body on success, for the
+// handful of calls (e.g. IngestObject) whose result is conveyed in the body.
+func (rf *remoteFedora) doBody(id, dsname, method, path string, content io.Reader) ([]byte, error) {
+	req, err := http.NewRequest(method, path, content)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	r, err := rf.client.Do(req)
+	rf.notify(id, dsname, req.Method, start, r, err)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	if err := translateStatus(r.StatusCode); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r.Body)
+}
+
+// translateStatus maps a Fedora REST API status code to one of the typed
+// errors callers are expected to check for.
+func translateStatus(code int) error {
+	switch code {
+	case 200, 201, 204:
+		return nil
+	case 404:
+		return ErrNotFound
+	case 401:
+		return ErrNotAuthorized
+	case 409:
+		return ErrChecksumMismatch
+	default:
+		return fmt.Errorf("Received status %d from fedora", code)
+	}
+}
+
+// NewChecksumVerifier wraps inner so the bytes returned by GetDatastream are
+// digested as they are streamed to the caller and compared against the best
+// checksum we know for the datastream: the X-Content-Md5/X-Content-Sha256
+// headers in ContentInfo (set when an R datastream redirects to bendo), or
+// failing that, DsInfo.Checksum fetched with a GetDatastreamInfo call. If the
+// digest disagrees, the final Read or Close returns ErrChecksumMismatch
+// instead of the usual io.EOF/nil. Datastreams with no known checksum are
+// passed through unverified.
+func NewChecksumVerifier(inner Fedora) Fedora {
+	return &checksumFedora{inner}
+}
+
+type checksumFedora struct {
+	Fedora
+}
+
+func (cf *checksumFedora) GetDatastream(id, dsname string) (io.ReadCloser, ContentInfo, error) {
+	rc, ci, err := cf.Fedora.GetDatastream(id, dsname)
+	if err != nil {
+		return rc, ci, err
+	}
+	want := ci.SHA256
+	h := sha256.New()
+	if want == "" {
+		want = ci.MD5
+		h = md5.New()
+	}
+	if want == "" {
+		if info, err := cf.Fedora.GetDatastreamInfo(id, dsname); err == nil {
+			want = info.Checksum
+			h = md5.New()
+		}
+	}
+	if want == "" {
+		return rc, ci, nil
+	}
+	return &checksumReader{rc: rc, h: h, want: strings.ToLower(want)}, ci, nil
+}
+
+// checksumReader wraps a datastream body, digesting it as it is read and
+// reporting ErrChecksumMismatch from the Read call that reaches EOF (or from
+// Close, if the caller stops reading early) when the digest disagrees with
+// the expected checksum.
+type checksumReader struct {
+	rc   io.ReadCloser
+	h    hash.Hash
+	want string
+	bad  bool
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	n, err := r.rc.Read(p)
+	if n > 0 {
+		r.h.Write(p[:n])
+	}
+	if err == io.EOF && !r.verify() {
+		return n, ErrChecksumMismatch
+	}
+	return n, err
+}
+
+func (r *checksumReader) Close() error {
+	err := r.rc.Close()
+	if !r.verify() {
+		return ErrChecksumMismatch
+	}
+	return err
+}
+
+// verify reports whether the digest computed so far matches r.want. The
+// result is cached so a mismatch, once seen, is remembered for Close even if
+// more data is read afterward.
+func (r *checksumReader) verify() bool {
+	if !r.bad && hex.EncodeToString(r.h.Sum(nil)) != r.want {
+		r.bad = true
+	}
+	return !r.bad
+}
+
 // Version returns the version number as an integer.
 // For example, if VersionID is "content.2" Version() will
 // return 2. It returns -1 on error.
@@ -164,7 +472,9 @@ func NewTestFedora() *TestFedora {
 // already been specified by Set().
 // Intended for testing. (Maybe move to a testing file?)
 type TestFedora struct {
-	data map[string]dsPair
+	data   map[string]dsPair
+	nextID int
+	rels   map[string][]Relationship
 }
 
 type dsPair struct {
@@ -216,3 +526,115 @@ func (tf *TestFedora) Set(id, dsname string, info DsInfo, value []byte) {
 	key := id + "/" + dsname
 	tf.data[key] = dsPair{info, value}
 }
+
+// GetRelationships returns the relationships set for id by SetRelationships,
+// or nil if none were set.
+func (tf *TestFedora) GetRelationships(id string) ([]Relationship, error) {
+	return tf.rels[id], nil
+}
+
+// SetRelationships records the RELS-EXT triples GetRelationships should
+// return for id, so handler tests can build small object graphs without a
+// real Fedora.
+func (tf *TestFedora) SetRelationships(id string, rels ...Relationship) {
+	if tf.rels == nil {
+		tf.rels = make(map[string][]Relationship)
+	}
+	tf.rels[id] = rels
+}
+
+// AddDatastream implements FedoraWriter by reading content into memory and
+// storing it the same way Set does.
+func (tf *TestFedora) AddDatastream(id, dsname string, content io.Reader, mimetype string) error {
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	tf.Set(id, dsname, DsInfo{MIMEType: mimetype}, data)
+	return nil
+}
+
+// ModifyDatastreamByValue implements FedoraWriter, overwriting the
+// datastream's content in place and bumping its VersionID, the way a real
+// Fedora modify would, so callers can exercise version-aware invalidation
+// (e.g. CachedFedora, PolicyEvaluator) against TestFedora.
+func (tf *TestFedora) ModifyDatastreamByValue(id, dsname string, content io.Reader, mimetype string) error {
+	key := id + "/" + dsname
+	v, ok := tf.data[key]
+	if !ok {
+		return ErrNotFound
+	}
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	info := v.info
+	info.MIMEType = mimetype
+	info.VersionID = nextVersionID(info.VersionID, dsname)
+	info.Size = fmt.Sprintf("%d", len(data))
+	tf.data[key] = dsPair{info, data}
+	return nil
+}
+
+// nextVersionID bumps the trailing integer of a VersionID of the form
+// "prefix.N", e.g. "content.0" -> "content.1". If versionID has no "." or
+// its suffix is not an integer, it starts a fresh series at dsname + ".1"
+// (Set's default for a first write is dsname + ".0").
+func nextVersionID(versionID, dsname string) string {
+	i := strings.LastIndex(versionID, ".")
+	if i == -1 {
+		return dsname + ".1"
+	}
+	n, err := strconv.Atoi(versionID[i+1:])
+	if err != nil {
+		return dsname + ".1"
+	}
+	return fmt.Sprintf("%s.%d", versionID[:i], n+1)
+}
+
+// ModifyDatastreamByReference implements FedoraWriter, pointing the
+// datastream at an external location instead of holding content for it.
+func (tf *TestFedora) ModifyDatastreamByReference(id, dsname, location string) error {
+	key := id + "/" + dsname
+	v, ok := tf.data[key]
+	if !ok {
+		return ErrNotFound
+	}
+	v.info.Location = location
+	v.info.LocationType = "URL"
+	v.content = nil
+	tf.data[key] = v
+	return nil
+}
+
+// PurgeDatastream implements FedoraWriter.
+func (tf *TestFedora) PurgeDatastream(id, dsname string) error {
+	key := id + "/" + dsname
+	if _, ok := tf.data[key]; !ok {
+		return ErrNotFound
+	}
+	delete(tf.data, key)
+	return nil
+}
+
+// IngestObject implements FedoraWriter by discarding the FOXML and minting a
+// new pid under the "test:" namespace.
+func (tf *TestFedora) IngestObject(foxml io.Reader) (string, error) {
+	if _, err := ioutil.ReadAll(foxml); err != nil {
+		return "", err
+	}
+	tf.nextID++
+	return fmt.Sprintf("test:%d", tf.nextID), nil
+}
+
+// PurgeObject implements FedoraWriter, removing every datastream belonging
+// to id.
+func (tf *TestFedora) PurgeObject(id string) error {
+	prefix := id + "/"
+	for key := range tf.data {
+		if strings.HasPrefix(key, prefix) {
+			delete(tf.data, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package fedora
+
+import "log"
+
+// NewLogObserver returns an Observer that writes one line per Fedora call to
+// logger, in the vein of the request logging disadis already does for its
+// own HTTP handlers.
+func NewLogObserver(logger *log.Logger) Observer {
+	return func(ci CallInfo) {
+		if ci.Err != nil {
+			logger.Printf("fedora %s %s/%s: %v (%v)", ci.Method, ci.ID, ci.Dsname, ci.Err, ci.Duration)
+			return
+		}
+		logger.Printf("fedora %s %s/%s: %d %d bytes (%v)", ci.Method, ci.ID, ci.Dsname, ci.Status, ci.Bytes, ci.Duration)
+	}
+}
+
+// Counter is the subset of prometheus.Counter (or any similar metrics
+// library's counter type) that NewCounterObserver needs. It lets this
+// package report call counts without taking a hard dependency on any
+// particular metrics library.
+type Counter interface {
+	Inc()
+}
+
+// NewCounterObserver returns an Observer that increments c once per Fedora
+// call, for wiring up a request-count metric such as a Prometheus counter.
+func NewCounterObserver(c Counter) Observer {
+	return func(CallInfo) {
+		c.Inc()
+	}
+}
+
+// Histogram is the subset of prometheus.Histogram (or any similar metrics
+// library's histogram type) that NewDurationObserver needs.
+type Histogram interface {
+	Observe(v float64)
+}
+
+// NewDurationObserver returns an Observer that reports each call's duration,
+// in seconds, to h, for wiring up a latency metric such as a Prometheus
+// histogram.
+func NewDurationObserver(h Histogram) Observer {
+	return func(ci CallInfo) {
+		h.Observe(ci.Duration.Seconds())
+	}
+}
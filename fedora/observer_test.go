@@ -0,0 +1,119 @@
+package fedora
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// recordingTransport wraps http.DefaultTransport so a test can assert that
+// an Option such as WithTransport actually routes requests through it.
+type recordingTransport struct {
+	called bool
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.called = true
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestObserverReportsCallInfoOnSuccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	var got CallInfo
+	transport := &recordingTransport{}
+	rf := NewRemote(srv.URL, "test:", WithTransport(transport), WithObserver(func(ci CallInfo) {
+		got = ci
+	}))
+
+	rc, _, err := rf.GetDatastream("1", "DS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.ReadAll(rc)
+	rc.Close()
+
+	if !transport.called {
+		t.Fatal("WithTransport's RoundTripper was never invoked")
+	}
+	if got.ID != "1" || got.Dsname != "DS" {
+		t.Fatalf("CallInfo ID/Dsname = %q/%q, want 1/DS", got.ID, got.Dsname)
+	}
+	if got.Method != http.MethodGet {
+		t.Fatalf("CallInfo Method = %q, want GET", got.Method)
+	}
+	if got.Status != 200 {
+		t.Fatalf("CallInfo Status = %d, want 200", got.Status)
+	}
+	if got.Duration <= 0 {
+		t.Fatal("CallInfo Duration was not populated")
+	}
+	if got.Err != nil {
+		t.Fatalf("CallInfo Err = %v, want nil", got.Err)
+	}
+}
+
+func TestObserverReportsCallInfoOnFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	var got CallInfo
+	rf := NewRemote(srv.URL, "test:", WithObserver(func(ci CallInfo) {
+		got = ci
+	}))
+
+	_, _, err := rf.GetDatastream("1", "DS")
+	if err != ErrNotFound {
+		t.Fatalf("GetDatastream err = %v, want ErrNotFound", err)
+	}
+	if got.Status != 404 {
+		t.Fatalf("CallInfo Status = %d, want 404", got.Status)
+	}
+	// The request itself succeeded at the transport level; Fedora's 404 is
+	// translated to ErrNotFound by the caller, not recorded as a transport
+	// error on CallInfo.
+	if got.Err != nil {
+		t.Fatalf("CallInfo Err = %v, want nil", got.Err)
+	}
+}
+
+func TestNewCounterObserverIncrementsOncePerCall(t *testing.T) {
+	c := &countingCounter{}
+	observe := NewCounterObserver(c)
+	observe(CallInfo{})
+	observe(CallInfo{})
+	if c.n != 2 {
+		t.Fatalf("counter = %d, want 2", c.n)
+	}
+}
+
+type countingCounter struct {
+	n int
+}
+
+func (c *countingCounter) Inc() {
+	c.n++
+}
+
+func TestNewDurationObserverReportsSeconds(t *testing.T) {
+	h := &recordingHistogram{}
+	observe := NewDurationObserver(h)
+	observe(CallInfo{Duration: 1500000000})
+	if len(h.values) != 1 || h.values[0] != 1.5 {
+		t.Fatalf("histogram values = %v, want [1.5]", h.values)
+	}
+}
+
+type recordingHistogram struct {
+	values []float64
+}
+
+func (h *recordingHistogram) Observe(v float64) {
+	h.values = append(h.values, v)
+}
@@ -0,0 +1,106 @@
+package fedora
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPolicyPermit(t *testing.T) {
+	cases := []struct {
+		name    string
+		policy  Policy
+		subject Subject
+		want    bool
+	}{
+		{"public read allows anyone", Policy{PublicRead: true}, Subject{}, true},
+		{"authenticated read allows an authenticated subject", Policy{AuthenticatedRead: true}, Subject{Authenticated: true}, true},
+		{"authenticated read denies an anonymous subject", Policy{AuthenticatedRead: true}, Subject{}, false},
+		{"group read allows a matching group", Policy{GroupRead: []string{"staff"}}, Subject{Groups: []string{"staff", "other"}}, true},
+		{"group read denies a non-matching group", Policy{GroupRead: []string{"staff"}}, Subject{Groups: []string{"public"}}, false},
+		{"no rules denies by default", Policy{}, Subject{Authenticated: true, Groups: []string{"staff"}}, false},
+	}
+	for _, c := range cases {
+		got, reason := c.policy.Permit(c.subject, "read")
+		if got != c.want {
+			t.Errorf("%s: Permit = %v (%s), want %v", c.name, got, reason, c.want)
+		}
+	}
+}
+
+func TestPolicyPermitUnknownAction(t *testing.T) {
+	p := Policy{PublicRead: true}
+	if ok, _ := p.Permit(Subject{}, "write"); ok {
+		t.Fatal("Permit allowed an unknown action")
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	const doc = `<rightsMetadata>
+  <access type="read">
+    <machine>
+      <group>public</group>
+      <group>staff</group>
+    </machine>
+  </access>
+</rightsMetadata>`
+	p, err := ParsePolicy(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.PublicRead {
+		t.Fatal("expected PublicRead to be set from group 'public'")
+	}
+	if len(p.GroupRead) != 1 || p.GroupRead[0] != "staff" {
+		t.Fatalf("GroupRead = %v, want [staff]", p.GroupRead)
+	}
+}
+
+const staffOnlyPolicy = `<rightsMetadata><access type="read"><machine><group>staff</group></machine></access></rightsMetadata>`
+const publicPolicy = `<rightsMetadata><access type="read"><machine><group>public</group></machine></access></rightsMetadata>`
+
+func TestPolicyEvaluatorCachesUntilVersionBumps(t *testing.T) {
+	tf := NewTestFedora()
+	tf.Set("pid:1", "POLICY", DsInfo{}, []byte(staffOnlyPolicy))
+	pe := NewPolicyEvaluator(tf)
+
+	if ok, _ := pe.Permit(Subject{Groups: []string{"public"}}, "read", "pid:1"); ok {
+		t.Fatal("expected deny for a subject outside the staff group")
+	}
+
+	// Overwriting the datastream content with Set under the same VersionID
+	// (unlike ModifyDatastreamByValue, which bumps it) simulates Fedora state drifting without
+	// disadis being told; the evaluator should keep serving its cached
+	// decision rather than re-fetching on every call.
+	tf.Set("pid:1", "POLICY", DsInfo{VersionID: "POLICY.0"}, []byte(publicPolicy))
+	if ok, _ := pe.Permit(Subject{}, "read", "pid:1"); ok {
+		t.Fatal("expected PolicyEvaluator to still be serving its cached policy")
+	}
+
+	// A real modify bumps VersionID, which should invalidate the cache.
+	if err := tf.ModifyDatastreamByValue("pid:1", "POLICY", strings.NewReader(publicPolicy), "text/xml"); err != nil {
+		t.Fatal(err)
+	}
+	ok, reason := pe.Permit(Subject{}, "read", "pid:1")
+	if !ok {
+		t.Fatalf("expected permit after version bump, got deny: %s", reason)
+	}
+}
+
+func TestPolicyEvaluatorFallsBackToRightsMetadata(t *testing.T) {
+	tf := NewTestFedora()
+	tf.Set("pid:1", "rightsMetadata", DsInfo{}, []byte(publicPolicy))
+	pe := NewPolicyEvaluator(tf)
+
+	if ok, reason := pe.Permit(Subject{}, "read", "pid:1"); !ok {
+		t.Fatalf("expected permit via rightsMetadata fallback, got deny: %s", reason)
+	}
+}
+
+func TestPolicyEvaluatorMissingPolicy(t *testing.T) {
+	tf := NewTestFedora()
+	pe := NewPolicyEvaluator(tf)
+
+	if ok, _ := pe.Permit(Subject{}, "read", "pid:missing"); ok {
+		t.Fatal("expected deny when no policy datastream exists")
+	}
+}
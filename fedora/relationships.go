@@ -0,0 +1,117 @@
+package fedora
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// Relationship is one RDF triple decoded from an object's RELS-EXT
+// datastream.
+type Relationship struct {
+	Subject   string
+	Predicate string
+	Object    string
+}
+
+// Well-known RELS-EXT predicates.
+const (
+	PredIsMemberOfCollection = "info:fedora/fedora-system:def/relations-external#isMemberOfCollection"
+	PredIsPartOf             = "info:fedora/fedora-system:def/relations-external#isPartOf"
+	PredIsConstituentOf      = "info:fedora/fedora-system:def/relations-external#isConstituentOf"
+	PredHasModel             = "info:fedora/fedora-system:def/model#hasModel"
+)
+
+// Relationships is the set of RELS-EXT triples asserted about a single
+// object, with typed accessors for the predicates disadis cares about.
+type Relationships []Relationship
+
+// Models returns the content models this object claims via hasModel.
+func (rs Relationships) Models() []string { return rs.objects(PredHasModel) }
+
+// Collections returns the objects this object is a member of via
+// isMemberOfCollection.
+func (rs Relationships) Collections() []string { return rs.objects(PredIsMemberOfCollection) }
+
+// Parents returns the objects this object is part of, via either isPartOf
+// or isConstituentOf.
+func (rs Relationships) Parents() []string {
+	return rs.objects(PredIsPartOf, PredIsConstituentOf)
+}
+
+func (rs Relationships) objects(preds ...string) []string {
+	var out []string
+	for _, r := range rs {
+		for _, p := range preds {
+			if r.Predicate == p {
+				out = append(out, r.Object)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// GetRelationships fetches id's RELS-EXT datastream and decodes its RDF/XML
+// into a slice of Relationship triples.
+func (rf *remoteFedora) GetRelationships(id string) ([]Relationship, error) {
+	rc, _, err := rf.GetDatastream(id, "RELS-EXT")
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return parseRelsExt(rc)
+}
+
+// parseRelsExt decodes a RELS-EXT RDF/XML document into its triples. Only
+// the rdf:Description/rdf:about form Fedora writes is understood: one
+// Description per subject, with each child element naming a predicate
+// (namespace URI + local name) and either an rdf:resource attribute or
+// literal text content giving the object.
+func parseRelsExt(r io.Reader) ([]Relationship, error) {
+	dec := xml.NewDecoder(r)
+	var rels []Relationship
+	var subject string
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "Description" {
+				subject = xmlAttr(t.Attr, "about")
+				continue
+			}
+			if subject == "" {
+				continue
+			}
+			predicate := t.Name.Space + t.Name.Local
+			object := xmlAttr(t.Attr, "resource")
+			if object == "" {
+				var text string
+				if err := dec.DecodeElement(&text, &t); err != nil {
+					return nil, err
+				}
+				object = text
+			}
+			rels = append(rels, Relationship{Subject: subject, Predicate: predicate, Object: object})
+		case xml.EndElement:
+			if t.Name.Local == "Description" {
+				subject = ""
+			}
+		}
+	}
+	return rels, nil
+}
+
+func xmlAttr(attrs []xml.Attr, local string) string {
+	for _, a := range attrs {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
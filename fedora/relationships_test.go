@@ -0,0 +1,74 @@
+package fedora
+
+import (
+	"strings"
+	"testing"
+)
+
+const relsExtXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+         xmlns:fedora="info:fedora/fedora-system:def/relations-external#"
+         xmlns:fedora-model="info:fedora/fedora-system:def/model#">
+  <rdf:Description rdf:about="info:fedora/test:1">
+    <fedora:isMemberOfCollection rdf:resource="info:fedora/test:collection"/>
+    <fedora:isPartOf rdf:resource="info:fedora/test:parent"/>
+    <fedora-model:hasModel rdf:resource="info:fedora/test:CModel"/>
+  </rdf:Description>
+</rdf:RDF>`
+
+func TestParseRelsExt(t *testing.T) {
+	rels, err := parseRelsExt(strings.NewReader(relsExtXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rels) != 3 {
+		t.Fatalf("got %d relationships, want 3", len(rels))
+	}
+	for _, r := range rels {
+		if r.Subject != "info:fedora/test:1" {
+			t.Fatalf("Subject = %q, want info:fedora/test:1", r.Subject)
+		}
+	}
+
+	rs := Relationships(rels)
+	if got := rs.Collections(); len(got) != 1 || got[0] != "info:fedora/test:collection" {
+		t.Fatalf("Collections() = %v", got)
+	}
+	if got := rs.Parents(); len(got) != 1 || got[0] != "info:fedora/test:parent" {
+		t.Fatalf("Parents() = %v", got)
+	}
+	if got := rs.Models(); len(got) != 1 || got[0] != "info:fedora/test:CModel" {
+		t.Fatalf("Models() = %v", got)
+	}
+}
+
+func TestRelationshipsParentsIncludesConstituentOf(t *testing.T) {
+	rs := Relationships{
+		{Subject: "test:1", Predicate: PredIsConstituentOf, Object: "test:parent"},
+	}
+	got := rs.Parents()
+	if len(got) != 1 || got[0] != "test:parent" {
+		t.Fatalf("Parents() = %v, want [test:parent]", got)
+	}
+}
+
+func TestTestFedoraSetRelationships(t *testing.T) {
+	tf := NewTestFedora()
+	tf.SetRelationships("test:1", Relationship{Subject: "test:1", Predicate: PredHasModel, Object: "test:CModel"})
+
+	rels, err := tf.GetRelationships("test:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rels) != 1 {
+		t.Fatalf("got %d relationships, want 1", len(rels))
+	}
+
+	rels, err = tf.GetRelationships("test:missing")
+	if err != nil {
+		t.Fatalf("GetRelationships for an id with no relationships set returned an error: %v", err)
+	}
+	if len(rels) != 0 {
+		t.Fatalf("got %d relationships for unknown id, want 0", len(rels))
+	}
+}
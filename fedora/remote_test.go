@@ -0,0 +1,151 @@
+package fedora
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAddDatastreamSendsMultipartContent(t *testing.T) {
+	var gotMethod, gotPath, gotContentType string
+	var gotPart []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatal(err)
+		}
+		file, header, err := r.FormFile("content")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		gotContentType = header.Header.Get("Content-Type")
+		gotPart, err = ioutil.ReadAll(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	rf := NewRemote(srv.URL, "test:")
+	err := rf.(FedoraWriter).AddDatastream("1", "DS", strings.NewReader("hello world"), "text/plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gotMethod != http.MethodPost {
+		t.Fatalf("method = %q, want POST", gotMethod)
+	}
+	if gotPath != "/objects/test:1/datastreams/DS" {
+		t.Fatalf("path = %q", gotPath)
+	}
+	if gotContentType != "text/plain" {
+		t.Fatalf("part Content-Type = %q, want text/plain", gotContentType)
+	}
+	if string(gotPart) != "hello world" {
+		t.Fatalf("part body = %q, want %q", gotPart, "hello world")
+	}
+}
+
+func TestModifyDatastreamByValueSendsMultipartContent(t *testing.T) {
+	var gotMethod string
+	var gotPart []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatal(err)
+		}
+		file, _, err := r.FormFile("content")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		gotPart, err = ioutil.ReadAll(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	rf := NewRemote(srv.URL, "test:")
+	err := rf.(FedoraWriter).ModifyDatastreamByValue("1", "DS", strings.NewReader("new content"), "text/plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gotMethod != http.MethodPut {
+		t.Fatalf("method = %q, want PUT", gotMethod)
+	}
+	if string(gotPart) != "new content" {
+		t.Fatalf("part body = %q, want %q", gotPart, "new content")
+	}
+}
+
+func TestDoRequestTranslatesStatusCodes(t *testing.T) {
+	cases := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusUnauthorized, ErrNotAuthorized},
+		{http.StatusConflict, ErrChecksumMismatch},
+		{http.StatusNoContent, nil},
+	}
+	for _, c := range cases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.status)
+		}))
+
+		rf := NewRemote(srv.URL, "test:")
+		err := rf.(FedoraWriter).PurgeDatastream("1", "DS")
+		if err != c.want {
+			t.Errorf("status %d: PurgeDatastream err = %v, want %v", c.status, err, c.want)
+		}
+		srv.Close()
+	}
+}
+
+func TestIngestObjectReturnsPidFromBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/objects/new" {
+			t.Fatalf("got %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte("test:42\n"))
+	}))
+	defer srv.Close()
+
+	rf := NewRemote(srv.URL, "test:")
+	pid, err := rf.(FedoraWriter).IngestObject(strings.NewReader("<foxml/>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pid != "test:42" {
+		t.Fatalf("pid = %q, want test:42", pid)
+	}
+}
+
+func TestPurgeObjectIssuesDelete(t *testing.T) {
+	var gotMethod, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	rf := NewRemote(srv.URL, "test:")
+	if err := rf.(FedoraWriter).PurgeObject("1"); err != nil {
+		t.Fatal(err)
+	}
+	if gotMethod != http.MethodDelete {
+		t.Fatalf("method = %q, want DELETE", gotMethod)
+	}
+	if gotPath != "/objects/test:1" {
+		t.Fatalf("path = %q", gotPath)
+	}
+}
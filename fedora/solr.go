@@ -0,0 +1,164 @@
+package fedora
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SolrService queries a Solr core that indexes Fedora objects, as a
+// companion to Fedora for the lookups Fedora's own REST search is too slow
+// or too limited to do well: finding the objects in a collection, counting
+// how many match a query, or resolving a PID without hitting Fedora itself.
+type SolrService struct {
+	baseURL string
+	core    string
+	client  *http.Client
+}
+
+// NewSolrService creates a SolrService pointed at the given core on the
+// Solr instance at baseURL. baseURL should not include the core name, e.g.
+//	NewSolrService("http://localhost:8983/solr", "fedora")
+func NewSolrService(baseURL, coreName string) *SolrService {
+	return &SolrService{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		core:    coreName,
+		client:  http.DefaultClient,
+	}
+}
+
+// SolrDoc is a single document returned by Solr. Its fields are whatever
+// the caller asked for via FindOpts.Fields, so callers type-assert the
+// values they expect (e.g. doc["PID"].(string)).
+type SolrDoc map[string]interface{}
+
+// FindOpts controls how FindObjects pages through results.
+type FindOpts struct {
+	// Fields is a comma separated Solr fl parameter. An empty Fields
+	// returns whatever fields Solr includes by default.
+	Fields string
+	// Rows is how many documents to fetch per page. 0 means a default of
+	// 50.
+	Rows int
+}
+
+// Iterator yields SolrDoc values one at a time, paging through the
+// underlying Solr result set as needed. Next returns io.EOF once every
+// matching document has been returned.
+type Iterator interface {
+	Next() (SolrDoc, error)
+}
+
+// FindObjects returns an Iterator over every document matching query,
+// transparently paging through Solr's start/rows parameters as the caller
+// calls Next.
+func (s *SolrService) FindObjects(query string, opts FindOpts) (Iterator, error) {
+	rows := opts.Rows
+	if rows <= 0 {
+		rows = 50
+	}
+	return &solrIterator{svc: s, query: query, fields: opts.Fields, rows: rows}, nil
+}
+
+// FindByPID returns the indexed document for pid, or ErrNotFound if Solr
+// has nothing indexed under that PID.
+func (s *SolrService) FindByPID(pid string) (SolrDoc, error) {
+	docs, _, err := s.query(`PID:"`+escapeSolrValue(pid)+`"`, "", 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, ErrNotFound
+	}
+	return docs[0], nil
+}
+
+// CountObjects returns the number of documents matching query, without
+// fetching any of them.
+func (s *SolrService) CountObjects(query string) (int, error) {
+	_, numFound, err := s.query(query, "", 0, 0)
+	return numFound, err
+}
+
+type solrIterator struct {
+	svc    *SolrService
+	query  string
+	fields string
+	rows   int
+	start  int
+	page   []SolrDoc
+	pos    int
+	done   bool
+}
+
+func (it *solrIterator) Next() (SolrDoc, error) {
+	for it.pos >= len(it.page) {
+		if it.done {
+			return nil, io.EOF
+		}
+		docs, numFound, err := it.svc.query(it.query, it.fields, it.start, it.rows)
+		if err != nil {
+			return nil, err
+		}
+		it.page = docs
+		it.pos = 0
+		it.start += len(docs)
+		if len(docs) == 0 || it.start >= numFound {
+			it.done = true
+		}
+	}
+	doc := it.page[it.pos]
+	it.pos++
+	return doc, nil
+}
+
+// query issues a single Solr /select request and returns the page of
+// documents along with Solr's reported total match count.
+func (s *SolrService) query(q, fl string, start, rows int) ([]SolrDoc, int, error) {
+	v := url.Values{}
+	v.Set("q", q)
+	v.Set("wt", "json")
+	v.Set("start", strconv.Itoa(start))
+	v.Set("rows", strconv.Itoa(rows))
+	if fl != "" {
+		v.Set("fl", fl)
+	}
+	path := s.baseURL + "/" + s.core + "/select?" + v.Encode()
+	r, err := s.client.Get(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != 200 {
+		return nil, 0, fmt.Errorf("Received status %d from solr", r.StatusCode)
+	}
+	var parsed struct {
+		Response struct {
+			NumFound int       `json:"numFound"`
+			Docs     []SolrDoc `json:"docs"`
+		} `json:"response"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&parsed); err != nil {
+		return nil, 0, err
+	}
+	return parsed.Response.Docs, parsed.Response.NumFound, nil
+}
+
+// solrSpecialChars are the characters Solr's query parser treats specially
+// and which must be backslash-escaped in a literal value.
+const solrSpecialChars = `+-&|!(){}[]^"~*?:\/`
+
+func escapeSolrValue(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(solrSpecialChars, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,145 @@
+package fedora
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// newSolrStub returns a test server that serves total documents, paging
+// correctly by the start/rows query parameters the way a real Solr /select
+// handler would.
+func newSolrStub(t *testing.T, total int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		start, err := strconv.Atoi(q.Get("start"))
+		if err != nil {
+			t.Fatalf("bad start param %q: %v", q.Get("start"), err)
+		}
+		rows, err := strconv.Atoi(q.Get("rows"))
+		if err != nil {
+			t.Fatalf("bad rows param %q: %v", q.Get("rows"), err)
+		}
+		var docs []SolrDoc
+		for i := start; i < start+rows && i < total; i++ {
+			docs = append(docs, SolrDoc{"PID": "test:" + strconv.Itoa(i)})
+		}
+		var resp struct {
+			Response struct {
+				NumFound int       `json:"numFound"`
+				Docs     []SolrDoc `json:"docs"`
+			} `json:"response"`
+		}
+		resp.Response.NumFound = total
+		resp.Response.Docs = docs
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestSolrIteratorPagesThroughAllResults(t *testing.T) {
+	const total = 5
+	srv := newSolrStub(t, total)
+	defer srv.Close()
+
+	svc := NewSolrService(srv.URL, "core")
+	it, err := svc.FindObjects("*:*", FindOpts{Rows: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for {
+		doc, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, doc["PID"].(string))
+	}
+	if len(got) != total {
+		t.Fatalf("got %d docs, want %d", len(got), total)
+	}
+	for i, pid := range got {
+		want := "test:" + strconv.Itoa(i)
+		if pid != want {
+			t.Fatalf("doc %d = %q, want %q", i, pid, want)
+		}
+	}
+	// Next should keep returning io.EOF once exhausted.
+	if _, err := it.Next(); err != io.EOF {
+		t.Fatalf("Next after exhaustion = %v, want io.EOF", err)
+	}
+}
+
+func TestSolrIteratorExactPageMultiple(t *testing.T) {
+	// total is an exact multiple of rows, exercising the off-by-one edge
+	// where start lands exactly on numFound after the last page.
+	const total = 4
+	srv := newSolrStub(t, total)
+	defer srv.Close()
+
+	svc := NewSolrService(srv.URL, "core")
+	it, err := svc.FindObjects("*:*", FindOpts{Rows: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	for {
+		_, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		count++
+	}
+	if count != total {
+		t.Fatalf("got %d docs, want %d", count, total)
+	}
+}
+
+func TestCountObjects(t *testing.T) {
+	srv := newSolrStub(t, 7)
+	defer srv.Close()
+
+	svc := NewSolrService(srv.URL, "core")
+	n, err := svc.CountObjects("*:*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 7 {
+		t.Fatalf("CountObjects = %d, want 7", n)
+	}
+}
+
+func TestFindByPID(t *testing.T) {
+	srv := newSolrStub(t, 1)
+	defer srv.Close()
+
+	svc := NewSolrService(srv.URL, "core")
+	doc, err := svc.FindByPID("test:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc["PID"] != "test:0" {
+		t.Fatalf("FindByPID doc = %v", doc)
+	}
+}
+
+func TestFindByPIDNotFound(t *testing.T) {
+	srv := newSolrStub(t, 0)
+	defer srv.Close()
+
+	svc := NewSolrService(srv.URL, "core")
+	if _, err := svc.FindByPID("test:missing"); err != ErrNotFound {
+		t.Fatalf("FindByPID on empty core = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,89 @@
+package fedora
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestTestFedoraWriter(t *testing.T) {
+	tf := NewTestFedora()
+
+	if err := tf.AddDatastream("pid:1", "DS", strings.NewReader("v1"), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := tf.GetDatastreamInfo("pid:1", "DS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.VersionID != "DS.0" {
+		t.Fatalf("VersionID after AddDatastream = %q, want %q", info.VersionID, "DS.0")
+	}
+
+	if err := tf.ModifyDatastreamByValue("pid:1", "DS", strings.NewReader("v2"), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	info2, err := tf.GetDatastreamInfo("pid:1", "DS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info2.VersionID != "DS.1" {
+		t.Fatalf("VersionID after ModifyDatastreamByValue = %q, want %q", info2.VersionID, "DS.1")
+	}
+
+	rc, _, err := tf.GetDatastream("pid:1", "DS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(rc)
+	rc.Close()
+	if string(data) != "v2" {
+		t.Fatalf("content = %q, want %q", data, "v2")
+	}
+
+	if err := tf.ModifyDatastreamByValue("pid:missing", "DS", strings.NewReader("x"), "text/plain"); err != ErrNotFound {
+		t.Fatalf("ModifyDatastreamByValue on missing datastream = %v, want ErrNotFound", err)
+	}
+
+	if err := tf.ModifyDatastreamByReference("pid:1", "DS", "http://example.org/content"); err != nil {
+		t.Fatal(err)
+	}
+	info3, err := tf.GetDatastreamInfo("pid:1", "DS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info3.Location != "http://example.org/content" || info3.LocationType != "URL" {
+		t.Fatalf("info after ModifyDatastreamByReference = %+v", info3)
+	}
+
+	if err := tf.PurgeDatastream("pid:1", "DS"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tf.GetDatastreamInfo("pid:1", "DS"); err != ErrNotFound {
+		t.Fatalf("GetDatastreamInfo after PurgeDatastream = %v, want ErrNotFound", err)
+	}
+
+	pid, err := tf.IngestObject(strings.NewReader("<foxml/>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pid == "" {
+		t.Fatal("IngestObject returned an empty pid")
+	}
+
+	if err := tf.AddDatastream("pid:2", "A", strings.NewReader("a"), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tf.AddDatastream("pid:2", "B", strings.NewReader("b"), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tf.PurgeObject("pid:2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tf.GetDatastreamInfo("pid:2", "A"); err != ErrNotFound {
+		t.Fatalf("GetDatastreamInfo(A) after PurgeObject = %v, want ErrNotFound", err)
+	}
+	if _, err := tf.GetDatastreamInfo("pid:2", "B"); err != ErrNotFound {
+		t.Fatalf("GetDatastreamInfo(B) after PurgeObject = %v, want ErrNotFound", err)
+	}
+}